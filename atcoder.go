@@ -0,0 +1,168 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	atcoderSubmissionsEndpoint = "https://kenkoooo.com/atcoder/atcoder-api/v3/user/submissions"
+	atcoderSubmissionsPerPage  = 500
+)
+
+type atcoderSubmission struct {
+	ID            int     `json:"id"`
+	EpochSecond   int64   `json:"epoch_second"`
+	ProblemID     string  `json:"problem_id"`
+	ContestID     string  `json:"contest_id"`
+	UserID        string  `json:"user_id"`
+	Language      string  `json:"language"`
+	Point         float64 `json:"point"`
+	Length        int     `json:"length"`
+	Result        string  `json:"result"`
+	ExecutionTime int     `json:"execution_time"`
+}
+
+func (s atcoderSubmission) toSubmission() Submission {
+	id := strconv.Itoa(s.ID)
+	return Submission{
+		ID:            id,
+		EpochSecond:   s.EpochSecond,
+		ContestID:     s.ContestID,
+		ProblemID:     s.ProblemID,
+		UserID:        s.UserID,
+		Language:      s.Language,
+		Point:         s.Point,
+		ExecutionTime: s.ExecutionTime,
+		URL:           fmt.Sprintf("https://atcoder.jp/contests/%s/submissions/%s", s.ContestID, id),
+	}
+}
+
+func atcoderSubmissionsRequest(userID string, fromSecond int64) (*http.Request, error) {
+	u, err := url.Parse(atcoderSubmissionsEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("user", userID)
+	q.Set("from_second", strconv.FormatInt(fromSecond, 10))
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	return req, nil
+}
+
+func fetchAtcoderSubmissionsOnce(userID string, fromSecond int64) ([]atcoderSubmission, error) {
+	req, err := atcoderSubmissionsRequest(userID, fromSecond)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("request to %s", req.URL.String())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+	r, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var ss []atcoderSubmission
+	if err := json.NewDecoder(r).Decode(&ss); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+func fetchAtcoderSubmissions(userID string, fromSecond int64) ([]atcoderSubmission, error) {
+	result := make([]atcoderSubmission, 0)
+	for {
+		ss, err := fetchAtcoderSubmissionsOnce(userID, fromSecond)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ss...)
+		if len(ss) < atcoderSubmissionsPerPage {
+			return result, nil
+		}
+
+		fromSecond = ss[len(ss)-1].EpochSecond
+	}
+}
+
+// filterNotAC filters out not AC submissions
+func filterNotAC(ss []atcoderSubmission) []atcoderSubmission {
+	result := make([]atcoderSubmission, 0, len(ss))
+	for _, s := range ss {
+		if s.Result == "AC" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// AtcoderService implements Service for atcoder.jp. Submissions are listed
+// via kenkoooo.com's community API and source code is scraped from the
+// per-submission page, since AtCoder itself has no public code endpoint.
+type AtcoderService struct{}
+
+func (AtcoderService) FetchSubmissions(userID string, since int64) ([]Submission, error) {
+	raw, err := fetchAtcoderSubmissions(userID, since)
+	if err != nil {
+		return nil, err
+	}
+	raw = filterNotAC(raw)
+	sort.Slice(raw, func(i, j int) bool {
+		return raw[i].EpochSecond < raw[j].EpochSecond
+	})
+
+	result := make([]Submission, 0, len(raw))
+	for _, s := range raw {
+		result = append(result, s.toSubmission())
+	}
+	return result, nil
+}
+
+func (AtcoderService) FetchCode(s Submission) (string, error) {
+	log.Printf("Requesting... %s", s.URL)
+
+	resp, err := http.Get(s.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	code := doc.Find(".linenums").First().Text()
+	if code == "" {
+		return "", errors.New("empty code block")
+	}
+	return code, nil
+}
+
+func (AtcoderService) PathLayout(s Submission) string {
+	return filepath.Join(s.ContestID, s.ProblemID, s.ID)
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAtcoderServicePathLayout(t *testing.T) {
+	s := Submission{ContestID: "abc123", ProblemID: "abc123_a", ID: "45678901"}
+
+	got := AtcoderService{}.PathLayout(s)
+	want := filepath.Join("abc123", "abc123_a", "45678901")
+
+	if got != want {
+		t.Errorf("PathLayout(%+v) = %q, want %q", s, got, want)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const codeforcesSubmissionsEndpoint = "https://codeforces.com/api/user.status"
+
+type codeforcesSubmission struct {
+	ID                  int64  `json:"id"`
+	CreationTimeSeconds int64  `json:"creationTimeSeconds"`
+	Verdict             string `json:"verdict"`
+	ProgrammingLanguage string `json:"programmingLanguage"`
+	Problem             struct {
+		ContestID int64  `json:"contestId"`
+		Index     string `json:"index"`
+	} `json:"problem"`
+	Author struct {
+		Members []struct {
+			Handle string `json:"handle"`
+		} `json:"members"`
+	} `json:"author"`
+}
+
+type codeforcesResponse struct {
+	Status string                 `json:"status"`
+	Result []codeforcesSubmission `json:"result"`
+}
+
+func (s codeforcesSubmission) toSubmission() Submission {
+	userID := ""
+	if len(s.Author.Members) > 0 {
+		userID = s.Author.Members[0].Handle
+	}
+	id := strconv.FormatInt(s.ID, 10)
+	contestID := strconv.FormatInt(s.Problem.ContestID, 10)
+	return Submission{
+		ID:          id,
+		EpochSecond: s.CreationTimeSeconds,
+		ContestID:   contestID,
+		ProblemID:   s.Problem.Index,
+		UserID:      userID,
+		Language:    s.ProgrammingLanguage,
+		URL:         fmt.Sprintf("https://codeforces.com/contest/%s/submission/%s", contestID, id),
+	}
+}
+
+func fetchCodeforcesSubmissions(userID string) ([]codeforcesSubmission, error) {
+	u, err := url.Parse(codeforcesSubmissionsEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("handle", userID)
+	u.RawQuery = q.Encode()
+	log.Printf("request to %s", u.String())
+
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+
+	var body codeforcesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Status != "OK" {
+		return nil, fmt.Errorf("codeforces API returned status %q", body.Status)
+	}
+	return body.Result, nil
+}
+
+// CodeforcesService implements Service for codeforces.com, backed by the
+// official user.status API and HTML scraping for source code, since
+// Codeforces has no public endpoint for submitted source.
+type CodeforcesService struct{}
+
+func (CodeforcesService) FetchSubmissions(userID string, since int64) ([]Submission, error) {
+	raw, err := fetchCodeforcesSubmissions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Submission, 0, len(raw))
+	for _, s := range raw {
+		if s.Verdict != "OK" {
+			continue
+		}
+		if s.CreationTimeSeconds <= since {
+			continue
+		}
+		result = append(result, s.toSubmission())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].EpochSecond < result[j].EpochSecond
+	})
+	return result, nil
+}
+
+func (CodeforcesService) FetchCode(s Submission) (string, error) {
+	log.Printf("Requesting... %s", s.URL)
+
+	resp, err := http.Get(s.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	code := doc.Find("#program-source-text").Text()
+	if code == "" {
+		return "", errors.New("empty code block")
+	}
+	return code, nil
+}
+
+func (CodeforcesService) PathLayout(s Submission) string {
+	return filepath.Join("codeforces", s.ContestID, s.ProblemID, s.ID)
+}
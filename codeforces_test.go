@@ -0,0 +1,17 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCodeforcesServicePathLayout(t *testing.T) {
+	s := Submission{ContestID: "1500", ProblemID: "A", ID: "123456789"}
+
+	got := CodeforcesService{}.PathLayout(s)
+	want := filepath.Join("codeforces", "1500", "A", "123456789")
+
+	if got != want {
+		t.Errorf("PathLayout(%+v) = %q, want %q", s, got, want)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	problemModelsURL   = "https://kenkoooo.com/atcoder/resources/problem-models.json"
+	difficultyCacheTTL = 24 * time.Hour
+)
+
+// difficultyBuckets are AtCoder Problems' rating colors, from weakest to
+// strongest, plus "unrated" for problems with no difficulty estimate yet.
+var difficultyBuckets = []string{"unrated", "gray", "brown", "green", "cyan", "blue", "yellow", "orange", "red"}
+
+type problemModel struct {
+	Difficulty *int `json:"difficulty"`
+}
+
+// difficultyCache is kenkoooo.com's problem-models.json, refetched at most
+// once per difficultyCacheTTL and persisted alongside the state index.
+type difficultyCache struct {
+	FetchedAt int64                   `json:"fetched_at"`
+	Models    map[string]problemModel `json:"models"`
+}
+
+func difficultyCachePath() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "."+appName, "difficulty-cache.json"), nil
+}
+
+// loadDifficultyCache returns a fresh-enough cache from disk, or fetches
+// and persists a new one from kenkoooo.com.
+func loadDifficultyCache() (*difficultyCache, error) {
+	path, err := difficultyCachePath()
+	if err != nil {
+		return nil, err
+	}
+	if fileExists(path) {
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			var cache difficultyCache
+			if err := json.Unmarshal(data, &cache); err == nil {
+				if time.Since(time.Unix(cache.FetchedAt, 0)) < difficultyCacheTTL {
+					return &cache, nil
+				}
+			}
+		}
+	}
+
+	models, err := fetchProblemModels()
+	if err != nil {
+		return nil, err
+	}
+	cache := &difficultyCache{FetchedAt: time.Now().Unix(), Models: models}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0o666); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+func fetchProblemModels() (map[string]problemModel, error) {
+	resp, err := http.Get(problemModelsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+
+	var models map[string]problemModel
+	if err := json.NewDecoder(resp.Body).Decode(&models); err != nil {
+		return nil, err
+	}
+	return models, nil
+}
+
+// difficultyBucket maps an AtCoder Problems difficulty rating to its
+// color bucket, matching the thresholds AtCoder Problems itself uses.
+func difficultyBucket(difficulty int) string {
+	switch {
+	case difficulty < 400:
+		return "gray"
+	case difficulty < 800:
+		return "brown"
+	case difficulty < 1200:
+		return "green"
+	case difficulty < 1600:
+		return "cyan"
+	case difficulty < 2000:
+		return "blue"
+	case difficulty < 2400:
+		return "yellow"
+	case difficulty < 2800:
+		return "orange"
+	default:
+		return "red"
+	}
+}
+
+// difficultyPathLayout lays submissions out as {bucket}/{contestID}/{problemID}/{submissionID},
+// so the archive can be browsed as a study resource ordered by difficulty.
+func difficultyPathLayout(cache *difficultyCache, s Submission) string {
+	bucket := "unrated"
+	if model, ok := cache.Models[s.ProblemID]; ok && model.Difficulty != nil {
+		bucket = difficultyBucket(*model.Difficulty)
+	}
+	return filepath.Join(bucket, s.ContestID, s.ProblemID, s.ID)
+}
+
+func isDifficultyBucket(name string) bool {
+	for _, b := range difficultyBuckets {
+		if b == name {
+			return true
+		}
+	}
+	return false
+}
+
+// regenerateDifficultyReadmes rebuilds a README.md per difficulty bucket
+// under repoPath, listing every solved problem found in that bucket's
+// directory tree. The tree itself is the source of truth, the same way
+// reindexCmd treats it.
+func regenerateDifficultyReadmes(repoPath string) error {
+	solved := map[string]map[string]bool{} // bucket -> "contestID/problemID"
+
+	err := filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || path == repoPath {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(repoPath, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+		if len(parts) != 3 || !isDifficultyBucket(parts[0]) {
+			return nil
+		}
+		bucket, contestID, problemID := parts[0], parts[1], parts[2]
+		if solved[bucket] == nil {
+			solved[bucket] = map[string]bool{}
+		}
+		solved[bucket][contestID+"/"+problemID] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for bucket, problems := range solved {
+		keys := make([]string, 0, len(problems))
+		for k := range problems {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "# %s problems\n\n", bucket)
+		for _, k := range keys {
+			parts := strings.SplitN(k, "/", 2)
+			contestID, problemID := parts[0], parts[1]
+			fmt.Fprintf(&b, "- [%s](https://atcoder.jp/contests/%s/tasks/%s)\n", problemID, contestID, problemID)
+		}
+
+		readmePath := filepath.Join(repoPath, bucket, "README.md")
+		if err := os.WriteFile(readmePath, []byte(b.String()), 0o666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitDifficultyReadmes stages every regenerated README.md and, if that
+// actually changed anything, commits the update.
+func commitDifficultyReadmes(w *git.Worktree, serviceConfig ServiceConfig, signKey *openpgp.Entity) error {
+	if err := w.AddGlob("*/README.md"); err != nil {
+		if err == git.ErrGlobNoMatches {
+			return nil
+		}
+		return fmt.Errorf("failed to stage difficulty READMEs: %w", err)
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, fs := range status {
+		if fs.Staging != git.Unmodified && fs.Staging != git.Untracked {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	_, err = w.Commit("Update difficulty READMEs", &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  serviceConfig.UserID,
+			Email: serviceConfig.UserEmail,
+			When:  time.Now(),
+		},
+		SignKey: signKey,
+	})
+	return err
+}
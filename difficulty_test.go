@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestDifficultyBucketBoundaries(t *testing.T) {
+	cases := []struct {
+		difficulty int
+		want       string
+	}{
+		{-1000, "gray"},
+		{399, "gray"},
+		{400, "brown"},
+		{799, "brown"},
+		{800, "green"},
+		{1199, "green"},
+		{1200, "cyan"},
+		{1599, "cyan"},
+		{1600, "blue"},
+		{1999, "blue"},
+		{2000, "yellow"},
+		{2399, "yellow"},
+		{2400, "orange"},
+		{2799, "orange"},
+		{2800, "red"},
+		{4000, "red"},
+	}
+	for _, c := range cases {
+		if got := difficultyBucket(c.difficulty); got != c.want {
+			t.Errorf("difficultyBucket(%d) = %q, want %q", c.difficulty, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfterSeconds(t *testing.T) {
+	got := parseRetryAfter("120")
+	want := 120 * time.Second
+	if got != want {
+		t.Errorf("parseRetryAfter(%q) = %s, want %s", "120", got, want)
+	}
+}
+
+func TestParseRetryAfterHTTPDate(t *testing.T) {
+	future := time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat)
+	got := parseRetryAfter(future)
+	if got <= 0 || got > 2*time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %s, want a positive duration close to 2m", future, got)
+	}
+}
+
+func TestParseRetryAfterEmptyOrInvalid(t *testing.T) {
+	for _, value := range []string{"", "not a valid value"} {
+		if got := parseRetryAfter(value); got != 0 {
+			t.Errorf("parseRetryAfter(%q) = %s, want 0", value, got)
+		}
+	}
+}
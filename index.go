@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const stateFileName = "state.json"
+
+// indexEntry tracks how far archiving has progressed for one
+// service+userID pair, so archiveCmd can resume instead of rescanning.
+type indexEntry struct {
+	LastEpochSecond int64           `json:"last_epoch_second"`
+	KnownIDs        map[string]bool `json:"known_ids"`
+}
+
+// stateIndex is the on-disk cursor store at ~/.procon-gardener/state.json.
+type stateIndex struct {
+	Entries map[string]indexEntry `json:"entries"`
+}
+
+func indexKey(serviceName, userID string) string {
+	return serviceName + ":" + userID
+}
+
+func stateFilePath() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "."+appName, stateFileName), nil
+}
+
+func loadIndex() (*stateIndex, error) {
+	path, err := stateFilePath()
+	if err != nil {
+		return nil, err
+	}
+	if !fileExists(path) {
+		return &stateIndex{Entries: map[string]indexEntry{}}, nil
+	}
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var idx stateIndex
+	if err := json.Unmarshal(bytes, &idx); err != nil {
+		return nil, err
+	}
+	if idx.Entries == nil {
+		idx.Entries = map[string]indexEntry{}
+	}
+	return &idx, nil
+}
+
+// saveIndex writes idx atomically, so a crash mid-archive never leaves a
+// truncated or corrupt state file behind.
+func saveIndex(idx *stateIndex) error {
+	path, err := stateFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	jsonBytes, err := json.MarshalIndent(idx, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, jsonBytes, 0o666); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, path)
+}
+
+// reindexCmd rebuilds a service's known-submission set from the submission
+// directories already present in its repository, for users migrating from
+// the pre-index layout where dedup was done by walking the tree.
+func reindexCmd(serviceName string) error {
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	serviceConfig, ok := config.Services[serviceName]
+	if !ok {
+		return fmt.Errorf("service %q is not configured, run `procon-gardener init --service %s` first", serviceName, serviceName)
+	}
+
+	knownIDs := map[string]bool{}
+	root := serviceConfig.RepositoryPath
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() == "README.md" {
+			// regenerateDifficultyReadmes writes these directly under a
+			// difficulty bucket directory, not under a submission ID one.
+			return nil
+		}
+		// a submission's archived file always lives directly under a
+		// directory named after its submission ID (see Service.PathLayout)
+		knownIDs[filepath.Base(filepath.Dir(path))] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	idx, err := loadIndex()
+	if err != nil {
+		return err
+	}
+	idx.Entries[indexKey(serviceName, serviceConfig.UserID)] = indexEntry{
+		LastEpochSecond: 0,
+		KnownIDs:        knownIDs,
+	}
+	if err := saveIndex(idx); err != nil {
+		return err
+	}
+	log.Printf("reindexed %d known submissions for %s", len(knownIDs), serviceName)
+	return nil
+}
@@ -1,23 +1,20 @@
 package main
 
 import (
-	"compress/gzip"
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
-	"net/http"
-	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"sort"
-	"strconv"
-	"strings"
+	"text/template"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/mitchellh/go-homedir"
@@ -25,121 +22,49 @@ import (
 	cli "github.com/urfave/cli/v2"
 )
 
-const (
-	appName             = "procon-gardener"
-	submissionsEndpoint = "https://kenkoooo.com/atcoder/atcoder-api/v3/user/submissions"
-	submissionsPerPage  = 500
-)
-
-type AtCoderSubmission struct {
-	ID            int     `json:"id"`
-	EpochSecond   int64   `json:"epoch_second"`
-	ProblemID     string  `json:"problem_id"`
-	ContestID     string  `json:"contest_id"`
-	UserID        string  `json:"user_id"`
-	Language      string  `json:"language"`
-	Point         float64 `json:"point"`
-	Length        int     `json:"length"`
-	Result        string  `json:"result"`
-	ExecutionTime int     `json:"execution_time"`
-}
-
-func dirExists(path string) bool {
-	info, err := os.Stat(path)
-	if err != nil && os.IsNotExist(err) {
-		return false
-	}
-	return info.IsDir()
-}
-
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
-}
+const appName = "procon-gardener"
 
-type Service struct {
+// ServiceConfig holds the settings a user configures for a single online
+// judge: where to archive its code and which account to archive from.
+type ServiceConfig struct {
 	RepositoryPath string `json:"repository_path"`
 	UserID         string `json:"user_id"`
 	UserEmail      string `json:"user_email"`
+	// SigningKey, if set, is the path to an armored GPG private key used
+	// to sign archive commits. SigningPassphrase decrypts it, if needed.
+	// SSH keys are not supported here; GPG is the only signing method.
+	SigningKey        string `json:"signing_key"`
+	SigningPassphrase string `json:"signing_passphrase"`
+	// Remote configures --pr mode: where to push archive branches and
+	// which upstream repository to open pull requests against.
+	Remote RemoteConfig `json:"remote"`
+	// Layout selects the on-disk directory layout. "" (the default) is the
+	// service's own PathLayout, e.g. {contestID}/{problemID}/{submissionID}
+	// for atcoder; "difficulty" buckets by
+	// AtCoder Problems difficulty rating instead (atcoder only).
+	Layout string `json:"layout"`
 }
 
 type Config struct {
-	Atcoder Service `json:"atcoder"`
+	Services    map[string]ServiceConfig `json:"services"`
+	Storage     StorageConfig            `json:"storage"`
+	Concurrency ConcurrencyConfig        `json:"concurrency"`
+	// CommitMessageTemplate is a text/template string rendered against a
+	// Submission for each archive commit. Defaults to
+	// defaultCommitMessageTemplate when empty.
+	CommitMessageTemplate string `json:"commit_message_template"`
 }
 
-func languageToFileName(language string) string {
-	name := "Main"
-	// e.g C++14 (GCC 5.4.1)
-	// C++14
-	language = strings.Split(language, "(")[0]
-	// remove extra last whitespace
-	language = strings.TrimSpace(language)
-
-	prefixes := map[string]string{
-		"C++":         ".cpp",
-		"Bash":        ".sh",
-		"Common Lisp": ".lisp",
-		"Python":      ".py",
-		"PyPy":        ".py",
-	}
-	for p, ext := range prefixes {
-		if strings.HasPrefix(language, p) {
-			return name + ext
-		}
-	}
+const defaultCommitMessageTemplate = "âœ… {{.ContestID}} {{.ProblemID}} {{.ExecutionTime}}ms {{.URL}}"
 
-	names := map[string]string{
-		"C":            ".c",
-		"C#":           ".cs",
-		"Clojure":      ".clj",
-		"D":            ".d",
-		"Fortran":      ".f08",
-		"Go":           ".go",
-		"Haskell":      ".hs",
-		"JavaScript":   ".js",
-		"Java":         ".java",
-		"OCaml":        ".ml",
-		"Pascal":       ".pas",
-		"Perl":         ".pl",
-		"PHP":          ".php",
-		"Ruby":         ".rb",
-		"Scala":        ".scala",
-		"Scheme":       ".scm",
-		"Main.txt":     ".txt",
-		"Visual Basic": ".vb",
-		"Objective-C":  ".m",
-		"Swift":        ".swift",
-		"Rust":         ".rs",
-		"Sed":          ".sed",
-		"Awk":          ".awk",
-		"Brainfuck":    ".bf",
-		"Standard ML":  ".sml",
-		"Crystal":      ".cr",
-		"F#":           ".fs",
-		"Unlambda":     ".unl",
-		"Lua":          ".lua",
-		"LuaJIT":       ".lua",
-		"MoonScript":   ".moon",
-		"Ceylon":       ".ceylon",
-		"Julia":        ".jl",
-		"Octave":       ".m",
-		"Nim":          ".nim",
-		"TypeScript":   ".ts",
-		"Perl6":        ".p6",
-		"Kotlin":       ".kt",
-		"COBOL":        ".cob",
-	}
-	for n, ext := range names {
-		if n == language {
-			return name + ext
-		}
-	}
-
-	log.Printf("Unknown ... %s", language)
-	return name + ".txt"
+// ConcurrencyConfig controls how archiveCmd parallelizes fetching. Zero
+// values fall back to defaultWorkers / defaultQPS.
+type ConcurrencyConfig struct {
+	Workers int     `json:"workers"`
+	QPS     float64 `json:"qps"`
 }
 
-func initCmd(strict bool) error {
+func initCmd(strict bool, serviceName string) error {
 	log.Println("Initialize your config...")
 	home, err := homedir.Dir()
 	if err != nil {
@@ -156,7 +81,9 @@ func initCmd(strict bool) error {
 	configFile := filepath.Join(configDir, "config.json")
 	if strict || !fileExists(configFile) {
 		// initial config
-		config := Config{Atcoder: Service{RepositoryPath: "", UserID: ""}}
+		config := Config{Services: map[string]ServiceConfig{
+			serviceName: {RepositoryPath: "", UserID: ""},
+		}}
 
 		jsonBytes, err := json.MarshalIndent(config, "", "\t")
 		if err != nil {
@@ -178,203 +105,198 @@ func loadConfig() (*Config, error) {
 	}
 	configDir := filepath.Join(home, "."+appName)
 	configFile := filepath.Join(configDir, "config.json")
-	bytes, err := ioutil.ReadFile(configFile)
+	data, err := ioutil.ReadFile(configFile)
 	if err != nil {
 		return nil, err
 	}
 	var config Config
-	if err = json.Unmarshal(bytes, &config); err != nil {
+	if err = json.Unmarshal(data, &config); err != nil {
 		log.Println(err)
 		return nil, err
 	}
 	return &config, nil
 }
 
-func archiveFile(code, fileName, path string, submission AtCoderSubmission) error {
-	if err := os.MkdirAll(path, 0o700); err != nil {
+func archiveCmd(serviceName string, full, pr bool) error {
+	config, err := loadConfig()
+	if err != nil {
 		return err
 	}
-
-	if err := os.WriteFile(filepath.Join(path, fileName), []byte(code), 0o666); err != nil {
-		return err
+	serviceConfig, ok := config.Services[serviceName]
+	if !ok {
+		return fmt.Errorf("service %q is not configured, run `procon-gardener init --service %s` first", serviceName, serviceName)
 	}
-	return nil
-}
-
-func submissionsRequest(userID string, fromSecond int64) (*http.Request, error) {
-	u, err := url.Parse(submissionsEndpoint)
+	svc, err := lookupService(serviceName)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	q := u.Query()
-	q.Set("user", userID)
-	q.Set("from_second", strconv.FormatInt(fromSecond, 10))
-	u.RawQuery = q.Encode()
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	storer, err := newStorer(config.Storage, serviceConfig.RepositoryPath)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	req.Header.Set("Accept-Encoding", "gzip")
-	return req, nil
-}
 
-func fetchSubmissionsOnce(userID string, fromSecond int64) ([]AtCoderSubmission, error) {
-	req, err := submissionsRequest(userID, fromSecond)
+	idx, err := loadIndex()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	log.Printf("request to %s", req.URL.String())
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+	key := indexKey(serviceName, serviceConfig.UserID)
+	entry := idx.Entries[key]
+	if entry.KnownIDs == nil {
+		entry.KnownIDs = map[string]bool{}
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status code is not OK: %s", resp.Status)
+
+	fromSecond := entry.LastEpochSecond
+	if full {
+		fromSecond = 0
 	}
-	r, err := gzip.NewReader(resp.Body)
+
+	ss, err := svc.FetchSubmissions(serviceConfig.UserID, fromSecond)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	var ss []AtCoderSubmission
-	if err := json.NewDecoder(r).Decode(&ss); err != nil {
-		return nil, err
+	ss = filterKnownIDs(entry.KnownIDs, ss)
+	log.Printf("Archiving %d code...", len(ss))
+
+	workers := config.Concurrency.Workers
+	if workers == 0 {
+		workers = defaultWorkers
+	}
+	qps := config.Concurrency.QPS
+	if qps == 0 {
+		qps = defaultQPS
 	}
-	return ss, nil
-}
 
-func fetchSubmissions(userID string) ([]AtCoderSubmission, error) {
-	result := make([]AtCoderSubmission, 0)
-	fromSecond := int64(0)
-	for {
-		ss, err := fetchSubmissionsOnce(userID, fromSecond)
-		if err != nil {
-			return nil, err
-		}
-		result = append(result, ss...)
-		if len(ss) < submissionsPerPage {
-			return result, nil
+	// git versioning only applies to the local backend, where the archive
+	// is a checkout the user maintains themselves.
+	var r *git.Repository
+	var w *git.Worktree
+	if config.Storage.Backend == "" || config.Storage.Backend == "local" {
+		if dirExists(filepath.Join(serviceConfig.RepositoryPath, ".git")) {
+			if r, err = git.PlainOpen(serviceConfig.RepositoryPath); err != nil {
+				return err
+			}
+			if w, err = r.Worktree(); err != nil {
+				return err
+			}
 		}
-
-		fromSecond = ss[len(ss)-1].EpochSecond
 	}
-}
 
-// filter not AC submissions
-func filterNotAC(ss []AtCoderSubmission) []AtCoderSubmission {
-	result := make([]AtCoderSubmission, 0, len(ss))
-	for _, s := range ss {
-		if s.Result == "AC" {
-			result = append(result, s)
+	var prBranch string
+	if pr {
+		if w == nil {
+			return errors.New("archive --pr requires a local git repository")
+		}
+		if serviceConfig.Remote.URL == "" {
+			return fmt.Errorf("service %q has no remote configured for --pr", serviceName)
 		}
+		originalBranch, err := currentBranchRef(r)
+		if err != nil {
+			return err
+		}
+		prBranch = fmt.Sprintf("procon-gardener/%s/%d", serviceName, time.Now().Unix())
+		if err := checkoutPRBranch(w, prBranch); err != nil {
+			return err
+		}
+		defer func() {
+			if err := restorePRBranch(w, originalBranch); err != nil {
+				log.Printf("failed to restore branch %s after archive --pr: %v", originalBranch, err)
+			}
+		}()
 	}
-	return result
-}
-
-func directoryPath(repoPath string, s AtCoderSubmission) string {
-	return filepath.Join(repoPath, s.ContestID, s.ProblemID, strconv.Itoa(s.ID))
-}
 
-func filterDirsExist(repoPath string, ss []AtCoderSubmission) []AtCoderSubmission {
-	result := make([]AtCoderSubmission, 0, len(ss))
-	for _, s := range ss {
-		if !dirExists(directoryPath(repoPath, s)) {
-			result = append(result, s)
+	var signKey *openpgp.Entity
+	if serviceConfig.SigningKey != "" {
+		if signKey, err = loadSigningKey(serviceConfig.SigningKey, serviceConfig.SigningPassphrase); err != nil {
+			return fmt.Errorf("failed to load signing key: %w", err)
 		}
 	}
-	return result
-}
 
-func archiveCmd() error {
-	config, err := loadConfig()
-	if err != nil {
-		return err
+	messageTemplate := config.CommitMessageTemplate
+	if messageTemplate == "" {
+		messageTemplate = defaultCommitMessageTemplate
 	}
-	ss, err := fetchSubmissions(config.Atcoder.UserID)
+	tmpl, err := template.New("commitMessage").Parse(messageTemplate)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid commit_message_template: %w", err)
 	}
 
-	ss = filterNotAC(ss)
-	ss = filterDirsExist(config.Atcoder.RepositoryPath, ss)
-	sort.Slice(ss, func(i, j int) bool {
-		return ss[i].EpochSecond < ss[j].EpochSecond
-	})
-
-	startTime := time.Now()
-	log.Printf("Archiving %d code...", len(ss))
-
-	for _, s := range ss {
-		time.Sleep(time.Until(startTime.Add(1500 * time.Millisecond)))
-		u := fmt.Sprintf("https://atcoder.jp/contests/%s/submissions/%d", s.ContestID, s.ID)
-		log.Printf("Requesting... %s", u)
-
-		resp, err := http.Get(u)
-		if err != nil {
-			return err
+	var diffCache *difficultyCache
+	if serviceName == "atcoder" && serviceConfig.Layout == "difficulty" {
+		if diffCache, err = loadDifficultyCache(); err != nil {
+			return fmt.Errorf("failed to load problem difficulty cache: %w", err)
 		}
-		defer resp.Body.Close()
-		startTime = time.Now()
-		if err != nil {
-			return err
-		}
-
-		doc, err := goquery.NewDocumentFromReader(resp.Body)
-		if err != nil {
-			return err
-		}
-		selection := doc.Find(".linenums")
-		for i := 0; i < selection.Length(); i++ {
-			code := selection.Eq(i).Text()
-			if code == "" {
-				return errors.New("Empty string...")
-			}
-			fileName := languageToFileName(s.Language)
-			archiveDirPath := directoryPath(config.Atcoder.RepositoryPath, s)
+	}
+	pathLayout := svc.PathLayout
+	if diffCache != nil {
+		pathLayout = func(s Submission) string { return difficultyPathLayout(diffCache, s) }
+	}
 
-			if err := archiveFile(code, fileName, archiveDirPath, s); err != nil {
-				return fmt.Errorf("fail to archive the code at %s: %w", filepath.Join(archiveDirPath, fileName), err)
-			}
-			log.Printf("archived the code at %s", filepath.Join(archiveDirPath, fileName))
-			// If the archive repo is the git repo
-			// git add and git commit
-			if !dirExists(filepath.Join(config.Atcoder.RepositoryPath, ".git")) {
-				continue
-			}
+	if err := runArchivePipeline(svc, ss, workers, qps, func(s Submission, code string) error {
+		fileName := languageToFileName(s.Language)
+		archiveKey := filepath.Join(pathLayout(s), fileName)
 
-			r, err := git.PlainOpen(config.Atcoder.RepositoryPath)
-			if err != nil {
-				return err
-			}
+		if err := storer.Put(context.Background(), archiveKey, []byte(code)); err != nil {
+			return fmt.Errorf("fail to archive the code at %s: %w", archiveKey, err)
+		}
+		log.Printf("archived the code at %s", archiveKey)
 
-			w, err := r.Worktree()
-			if err != nil {
-				return err
-			}
+		if w != nil {
 			// add source code
-			dirRelativePath, err := filepath.Rel(config.Atcoder.RepositoryPath, archiveDirPath)
-			if err != nil {
+			if _, err := w.Add(archiveKey); err != nil {
 				return err
 			}
-			_, err = w.Add(filepath.Join(dirRelativePath, fileName))
-			if err != nil {
+
+			var messageBuf bytes.Buffer
+			if err := tmpl.Execute(&messageBuf, s); err != nil {
 				return err
 			}
-
-			message := fmt.Sprintf("âœ… %s %s %dms %s", s.ContestID, s.ProblemID, s.ExecutionTime, u)
-			_, err = w.Commit(message, &git.CommitOptions{
+			if _, err := w.Commit(messageBuf.String(), &git.CommitOptions{
 				Author: &object.Signature{
 					Name:  s.UserID,
-					Email: config.Atcoder.UserEmail,
+					Email: serviceConfig.UserEmail,
 					When:  time.Unix(s.EpochSecond, 0),
 				},
-			})
-			if err != nil {
+				SignKey: signKey,
+			}); err != nil {
 				return err
 			}
 		}
+
+		// Only mark the submission known once it's durably archived (and,
+		// for the local backend, committed) — otherwise a failure here
+		// would leave it written to disk but silently skipped forever.
+		entry.KnownIDs[s.ID] = true
+		if s.EpochSecond > entry.LastEpochSecond {
+			entry.LastEpochSecond = s.EpochSecond
+		}
+		idx.Entries[key] = entry
+		return saveIndex(idx)
+	}); err != nil {
+		return err
 	}
+
+	if diffCache != nil && (config.Storage.Backend == "" || config.Storage.Backend == "local") {
+		if err := regenerateDifficultyReadmes(serviceConfig.RepositoryPath); err != nil {
+			return fmt.Errorf("failed to regenerate difficulty READMEs: %w", err)
+		}
+		if w != nil {
+			if err := commitDifficultyReadmes(w, serviceConfig, signKey); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !pr || len(ss) == 0 {
+		return nil
+	}
+	if err := pushPRBranch(r, serviceConfig.Remote, prBranch); err != nil {
+		return fmt.Errorf("failed to push %s: %w", prBranch, err)
+	}
+	title := fmt.Sprintf("Archive %d %s submissions", len(ss), serviceName)
+	if err := openPullRequest(serviceConfig.Remote, prBranch, title); err != nil {
+		return fmt.Errorf("failed to open pull request: %w", err)
+	}
+	log.Printf("opened a pull request from %s", prBranch)
 	return nil
 }
 
@@ -386,7 +308,7 @@ func editCmd() error {
 	configFile := filepath.Join(home, "."+appName, "config.json")
 	// Config file not found, force to run an init cmd
 	if !fileExists(configFile) {
-		return initCmd(true)
+		return initCmd(true, "atcoder")
 	}
 
 	editor := os.Getenv("EDITOR")
@@ -409,16 +331,34 @@ func main() {
 				Name:    "archive",
 				Aliases: []string{"a"},
 				Usage:   "archive your AC submissions",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "service", Aliases: []string{"s"}, Value: "atcoder", Usage: "online judge to use (atcoder, codeforces)"},
+					&cli.BoolFlag{Name: "full", Usage: "ignore the saved cursor and resync all submissions"},
+					&cli.BoolFlag{Name: "pr", Usage: "commit to a new branch, push it to the fork configured in remote and open a pull request, instead of committing to the current branch"},
+				},
 				Action: func(c *cli.Context) error {
-					return archiveCmd()
+					return archiveCmd(c.String("service"), c.Bool("full"), c.Bool("pr"))
+				},
+			},
+			{
+				Name:  "reindex",
+				Usage: "rebuild the local index from a service's existing repository tree",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "service", Aliases: []string{"s"}, Value: "atcoder", Usage: "online judge to use (atcoder, codeforces)"},
+				},
+				Action: func(c *cli.Context) error {
+					return reindexCmd(c.String("service"))
 				},
 			},
 			{
 				Name:    "init",
 				Aliases: []string{"i"},
 				Usage:   "initialize your config",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "service", Aliases: []string{"s"}, Value: "atcoder", Usage: "online judge to use (atcoder, codeforces)"},
+				},
 				Action: func(c *cli.Context) error {
-					return initCmd(true)
+					return initCmd(true, c.String("service"))
 				},
 			},
 			{
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+const prForkRemoteName = "procon-gardener-fork"
+
+// RemoteConfig points --pr mode at a user-owned fork to push archive
+// branches to, and the upstream repository to open pull requests against.
+type RemoteConfig struct {
+	URL           string `json:"url"`
+	Token         string `json:"token"`
+	SSHKeyPath    string `json:"ssh_key_path"`
+	ForkOwner     string `json:"fork_owner"`
+	UpstreamOwner string `json:"upstream_owner"`
+	UpstreamRepo  string `json:"upstream_repo"`
+	Provider      string `json:"provider"` // "github" or "gitea"
+	// BaseBranch is the upstream branch to open pull requests against.
+	// Defaults to "main" when empty.
+	BaseBranch string `json:"base_branch"`
+}
+
+func remoteAuth(remote RemoteConfig) (transport.AuthMethod, error) {
+	if remote.SSHKeyPath != "" {
+		return ssh.NewPublicKeysFromFile("git", remote.SSHKeyPath, "")
+	}
+	return &githttp.BasicAuth{Username: "x-access-token", Password: remote.Token}, nil
+}
+
+// currentBranchRef returns the repository's currently checked-out branch,
+// so PR mode can restore it after the temporary archive branch is pushed.
+func currentBranchRef(r *git.Repository) (plumbing.ReferenceName, error) {
+	head, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Name(), nil
+}
+
+// checkoutPRBranch creates and checks out a fresh branch for one archive
+// batch, so its commits land in a pull request instead of on the default
+// branch.
+func checkoutPRBranch(w *git.Worktree, branchName string) error {
+	return w.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(branchName),
+		Create: true,
+	})
+}
+
+// restorePRBranch checks the worktree back out to original, so a
+// subsequent archive --pr run branches from the user's actual branch
+// instead of from the tip of this run's temporary one.
+func restorePRBranch(w *git.Worktree, original plumbing.ReferenceName) error {
+	return w.Checkout(&git.CheckoutOptions{Branch: original})
+}
+
+// pushPRBranch ensures a remote pointing at the user's fork exists and
+// pushes branchName to it.
+func pushPRBranch(r *git.Repository, remote RemoteConfig, branchName string) error {
+	_, err := r.CreateRemote(&config.RemoteConfig{
+		Name: prForkRemoteName,
+		URLs: []string{remote.URL},
+	})
+	if err != nil && err != git.ErrRemoteExists {
+		return err
+	}
+
+	auth, err := remoteAuth(remote)
+	if err != nil {
+		return err
+	}
+
+	refSpec := config.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", branchName, branchName))
+	return r.Push(&git.PushOptions{
+		RemoteName: prForkRemoteName,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Auth:       auth,
+	})
+}
+
+// openPullRequest opens a pull request against remote's upstream
+// repository from branchName, via the GitHub or Gitea REST API.
+func openPullRequest(remote RemoteConfig, branchName, title string) error {
+	var apiURL string
+	if remote.Provider == "gitea" {
+		apiURL = fmt.Sprintf("%s/api/v1/repos/%s/%s/pulls", remote.URL, remote.UpstreamOwner, remote.UpstreamRepo)
+	} else {
+		apiURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls", remote.UpstreamOwner, remote.UpstreamRepo)
+	}
+
+	base := remote.BaseBranch
+	if base == "" {
+		base = "main"
+	}
+	body, err := json.Marshal(map[string]string{
+		"title": title,
+		"head":  remote.ForkOwner + ":" + branchName,
+		"base":  base,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, apiURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "token "+remote.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to open pull request: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import "fmt"
+
+// Submission is a single accepted submission, normalized across online
+// judges so that archiveCmd never has to know which service produced it.
+type Submission struct {
+	ID            string
+	EpochSecond   int64
+	ContestID     string
+	ProblemID     string
+	UserID        string
+	Language      string
+	Point         float64
+	ExecutionTime int
+	URL           string
+}
+
+// Service is implemented once per online judge. It hides everything
+// judge-specific (API shape, HTML scraping, directory conventions) behind
+// three operations that archiveCmd can drive generically.
+type Service interface {
+	// FetchSubmissions returns AC submissions for userID created after
+	// the given epoch second.
+	FetchSubmissions(userID string, since int64) ([]Submission, error)
+	// FetchCode returns the full source code of a submission.
+	FetchCode(s Submission) (string, error)
+	// PathLayout returns the directory, relative to the repository root,
+	// that a submission's code should be archived under.
+	PathLayout(s Submission) string
+}
+
+// services holds every online judge procon-gardener knows how to archive,
+// keyed by the name users pass via --service.
+var services = map[string]Service{
+	"atcoder":    AtcoderService{},
+	"codeforces": CodeforcesService{},
+}
+
+func lookupService(name string) (Service, error) {
+	s, ok := services[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown service %q", name)
+	}
+	return s, nil
+}
+
+// filterKnownIDs drops submissions already recorded in the on-disk index,
+// so a resumed archive run doesn't refetch and rewrite them.
+func filterKnownIDs(knownIDs map[string]bool, ss []Submission) []Submission {
+	result := make([]Submission, 0, len(ss))
+	for _, s := range ss {
+		if !knownIDs[s.ID] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
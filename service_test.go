@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestFilterKnownIDs(t *testing.T) {
+	ss := []Submission{
+		{ID: "1"},
+		{ID: "2"},
+		{ID: "3"},
+	}
+	known := map[string]bool{"2": true}
+
+	got := filterKnownIDs(known, ss)
+
+	if len(got) != 2 {
+		t.Fatalf("got %d submissions, want 2: %+v", len(got), got)
+	}
+	for _, s := range got {
+		if s.ID == "2" {
+			t.Errorf("known submission %q was not filtered out", s.ID)
+		}
+	}
+}
+
+func TestFilterKnownIDsEmptyKnown(t *testing.T) {
+	ss := []Submission{{ID: "1"}, {ID: "2"}}
+
+	got := filterKnownIDs(map[string]bool{}, ss)
+
+	if len(got) != len(ss) {
+		t.Fatalf("got %d submissions, want %d", len(got), len(ss))
+	}
+}
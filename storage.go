@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// StorageConfig selects and configures where archived source code is
+// written. Backend is "local" (the default, writing under a service's
+// RepositoryPath) or "minio" for an S3-compatible object store.
+type StorageConfig struct {
+	Backend   string `json:"backend"`
+	Endpoint  string `json:"endpoint"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	Bucket    string `json:"bucket"`
+	UseSSL    bool   `json:"use_ssl"`
+}
+
+// Storer persists a single archived file under key, relative to whatever
+// root the implementation was configured with.
+type Storer interface {
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// newStorer builds the Storer described by cfg. localRoot is used only by
+// the local backend, typically a service's RepositoryPath.
+func newStorer(cfg StorageConfig, localRoot string) (Storer, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return localStorer{root: localRoot}, nil
+	case "minio":
+		return newMinioStorer(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+type localStorer struct {
+	root string
+}
+
+func (s localStorer) Put(ctx context.Context, key string, data []byte) error {
+	path := filepath.Join(s.root, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o666)
+}
+
+type minioStorer struct {
+	client *minio.Client
+	bucket string
+}
+
+func newMinioStorer(cfg StorageConfig) (*minioStorer, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &minioStorer{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *minioStorer) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{})
+	return err
+}
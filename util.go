@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil && os.IsNotExist(err) {
+		return false
+	}
+	return info.IsDir()
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func languageToFileName(language string) string {
+	name := "Main"
+	// e.g C++14 (GCC 5.4.1)
+	// C++14
+	language = strings.Split(language, "(")[0]
+	// remove extra last whitespace
+	language = strings.TrimSpace(language)
+
+	prefixes := map[string]string{
+		"C++":         ".cpp",
+		"Bash":        ".sh",
+		"Common Lisp": ".lisp",
+		"Python":      ".py",
+		"PyPy":        ".py",
+	}
+	for p, ext := range prefixes {
+		if strings.HasPrefix(language, p) {
+			return name + ext
+		}
+	}
+
+	names := map[string]string{
+		"C":            ".c",
+		"C#":           ".cs",
+		"Clojure":      ".clj",
+		"D":            ".d",
+		"Fortran":      ".f08",
+		"Go":           ".go",
+		"Haskell":      ".hs",
+		"JavaScript":   ".js",
+		"Java":         ".java",
+		"OCaml":        ".ml",
+		"Pascal":       ".pas",
+		"Perl":         ".pl",
+		"PHP":          ".php",
+		"Ruby":         ".rb",
+		"Scala":        ".scala",
+		"Scheme":       ".scm",
+		"Main.txt":     ".txt",
+		"Visual Basic": ".vb",
+		"Objective-C":  ".m",
+		"Swift":        ".swift",
+		"Rust":         ".rs",
+		"Sed":          ".sed",
+		"Awk":          ".awk",
+		"Brainfuck":    ".bf",
+		"Standard ML":  ".sml",
+		"Crystal":      ".cr",
+		"F#":           ".fs",
+		"Unlambda":     ".unl",
+		"Lua":          ".lua",
+		"LuaJIT":       ".lua",
+		"MoonScript":   ".moon",
+		"Ceylon":       ".ceylon",
+		"Julia":        ".jl",
+		"Octave":       ".m",
+		"Nim":          ".nim",
+		"TypeScript":   ".ts",
+		"Perl6":        ".p6",
+		"Kotlin":       ".kt",
+		"COBOL":        ".cob",
+	}
+	for n, ext := range names {
+		if n == language {
+			return name + ext
+		}
+	}
+
+	log.Printf("Unknown ... %s", language)
+	return name + ".txt"
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestLanguageToFileName(t *testing.T) {
+	cases := []struct {
+		language string
+		want     string
+	}{
+		{"C++14 (GCC 5.4.1)", "Main.cpp"},
+		{"Python (3.8.2)", "Main.py"},
+		{"PyPy3 (7.3.0)", "Main.py"},
+		{"Go", "Main.go"},
+		{"Rust", "Main.rs"},
+		{"some unknown language", "Main.txt"},
+	}
+	for _, c := range cases {
+		if got := languageToFileName(c.language); got != c.want {
+			t.Errorf("languageToFileName(%q) = %q, want %q", c.language, got, c.want)
+		}
+	}
+}
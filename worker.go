@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultWorkers    = 4
+	defaultQPS        = 1.0 / 1.5
+	maxFetchRetries   = 5
+	initialRetryDelay = 2 * time.Second
+)
+
+// fetchResult pairs a submission with the code fetched for it, or the
+// error fetching it produced after retries were exhausted. index is the
+// submission's position in the original, epoch-ascending ss slice.
+type fetchResult struct {
+	index      int
+	submission Submission
+	code       string
+	err        error
+}
+
+// runArchivePipeline fetches ss concurrently across workers goroutines,
+// sharing a token-bucket limiter so the combined request rate to the judge
+// never exceeds qps regardless of worker count. Results race in over the
+// fetched channel, but are handed to commit strictly in ss's order: a
+// later submission finishing its fetch before an earlier one is still
+// retrying must not get committed first, or the persisted cursor could
+// advance past a submission that goes on to permanently fail. commit is
+// called one at a time, in a single goroutine, since go-git's worktree is
+// not safe for concurrent mutation.
+func runArchivePipeline(svc Service, ss []Submission, workers int, qps float64, commit func(Submission, string) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	limiter := rate.NewLimiter(rate.Limit(qps), 1)
+
+	type job struct {
+		index      int
+		submission Submission
+	}
+	jobs := make(chan job)
+	fetched := make(chan fetchResult)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				code, err := fetchCodeWithRetry(limiter, svc, j.submission)
+				fetched <- fetchResult{index: j.index, submission: j.submission, code: code, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for i, s := range ss {
+			jobs <- job{index: i, submission: s}
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(fetched)
+	}()
+
+	pending := make(map[int]fetchResult, len(ss))
+	next := 0
+	var firstErr error
+	for result := range fetched {
+		pending[result.index] = result
+		for {
+			result, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			if result.err != nil {
+				log.Printf("giving up on %s %s: %v", result.submission.ContestID, result.submission.ProblemID, result.err)
+				if firstErr == nil {
+					firstErr = result.err
+				}
+				continue
+			}
+			if firstErr != nil {
+				// an earlier submission failed; committing this one would
+				// move the cursor past it
+				continue
+			}
+			if err := commit(result.submission, result.code); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// fetchCodeWithRetry calls svc.FetchCode, retrying on a retryable
+// httpStatusError (429 or 5xx) with exponential backoff, honoring
+// Retry-After when the judge sends one.
+func fetchCodeWithRetry(limiter *rate.Limiter, svc Service, s Submission) (string, error) {
+	delay := initialRetryDelay
+	for attempt := 0; ; attempt++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			return "", err
+		}
+
+		code, err := svc.FetchCode(s)
+		if err == nil {
+			return code, nil
+		}
+
+		statusErr, ok := err.(*httpStatusError)
+		if !ok || !statusErr.Retryable() || attempt >= maxFetchRetries {
+			return "", err
+		}
+
+		wait := statusErr.RetryAfter
+		if wait <= 0 {
+			wait = delay
+			delay *= 2
+		}
+		log.Printf("retrying %s %s after %s: %v", s.ContestID, s.ProblemID, wait, err)
+		time.Sleep(wait)
+	}
+}